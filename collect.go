@@ -0,0 +1,151 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package errgroup
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// collectorState holds the bookkeeping shared by a Collector's tasks. It is
+// kept separate from Collector itself so that the goroutines spawned by Go
+// — which must reference this state to record their result — never hold a
+// reference to the Collector, letting it become unreachable (and so be
+// finalized) independently of any still-running task.
+type collectorState[T any] struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	out    []T
+	queue  []T
+	closed bool
+
+	startForward sync.Once
+	results      chan T
+}
+
+// A Collector runs tasks that each produce a typed result, submitting them
+// to an underlying Group so that an error or panic in one task cancels the
+// rest. Results are available as an ordered slice from Wait, and can also be
+// streamed, in completion order, from Results; the Results channel is closed
+// only after Wait has been called.
+//
+// The zero Collector is not usable; construct one with NewCollector.
+type Collector[T any] struct {
+	g   *Group
+	ctx context.Context
+	st  *collectorState[T]
+}
+
+// NewCollector returns a Collector whose tasks run under a new Group
+// derived from ctx, together with the Context passed to those tasks. The
+// Context is canceled under the same conditions as the one returned by New.
+func NewCollector[T any](ctx context.Context) (*Collector[T], context.Context) {
+	g, ctx := New(ctx)
+	st := &collectorState[T]{results: make(chan T)}
+	st.cond = sync.NewCond(&st.mu)
+	c := &Collector[T]{g: g, ctx: ctx, st: st}
+	// If the caller drops c without ever calling Wait, cancel the
+	// underlying Group's Context so tasks blocked on ctx.Done() can exit
+	// instead of leaking, and release st so a forward goroutine started by
+	// a prior call to Results isn't left blocked forever; see Group's own
+	// Stop method. This only helps once no task goroutine still
+	// references c, which Go (below) takes care not to do.
+	runtime.SetFinalizer(c, func(c *Collector[T]) {
+		c.g.cancel()
+		c.st.closeAndBroadcast()
+	})
+	return c, ctx
+}
+
+// Go submits task to the Collector. task receives the Context returned by
+// NewCollector, and its result is recorded in submission order for Wait and
+// published, in completion order, on Results.
+//
+// As with Group.Go, the first task that returns a non-nil error, panics, or
+// invokes runtime.Goexit cancels the Context and every other task submitted
+// to the Collector.
+func (c *Collector[T]) Go(task func(ctx context.Context) (T, error)) {
+	st := c.st
+	ctx := c.ctx
+
+	st.mu.Lock()
+	idx := len(st.out)
+	var zero T
+	st.out = append(st.out, zero)
+	st.mu.Unlock()
+
+	c.g.Go(func() error {
+		val, err := task(ctx)
+		if err != nil {
+			return err
+		}
+		st.mu.Lock()
+		st.out[idx] = val
+		st.queue = append(st.queue, val)
+		st.cond.Signal()
+		st.mu.Unlock()
+		return nil
+	})
+}
+
+// Wait blocks until every task submitted via Go has completed, then returns
+// their results ordered by submission, alongside the first error (if any)
+// in the same sense as Group.Wait. As with Group.Wait, Wait panics or
+// invokes runtime.Goexit if a task did.
+//
+// Wait also cancels the Context returned by NewCollector and, once all
+// tasks have completed, marks the channel returned by Results to be closed
+// as soon as every queued result has been received from it — even when a
+// task returned an error, panicked, or invoked runtime.Goexit.
+func (c *Collector[T]) Wait() ([]T, error) {
+	st := c.st
+	defer func() {
+		c.g.cancel()
+		st.closeAndBroadcast()
+	}()
+	return st.out, c.g.Wait()
+}
+
+// closeAndBroadcast marks st closed and wakes any goroutine blocked in
+// forward. It is called both by Wait and by the Collector's finalizer, so
+// that a forward goroutine started by a call to Results is also released
+// if the Collector is dropped without ever calling Wait.
+func (st *collectorState[T]) closeAndBroadcast() {
+	st.mu.Lock()
+	st.closed = true
+	st.cond.Broadcast()
+	st.mu.Unlock()
+}
+
+// Results returns a channel of successful task results, delivered in
+// completion order as tasks submitted via Go finish. The channel is closed,
+// once drained, by a call to Wait; Results is meant to be read alongside a
+// concurrent call to Wait, not as a substitute for it.
+func (c *Collector[T]) Results() <-chan T {
+	st := c.st
+	st.startForward.Do(func() { go st.forward() })
+	return st.results
+}
+
+// forward drains queued results onto the public Results channel, blocking
+// only on the channel send itself, and closes the channel once the state
+// has been marked closed and the queue has been drained.
+func (st *collectorState[T]) forward() {
+	defer close(st.results)
+	for {
+		st.mu.Lock()
+		for len(st.queue) == 0 && !st.closed {
+			st.cond.Wait()
+		}
+		if len(st.queue) == 0 {
+			st.mu.Unlock()
+			return
+		}
+		val := st.queue[0]
+		st.queue = st.queue[1:]
+		st.mu.Unlock()
+		st.results <- val
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/DmitriyMV/errgroup"
+)
+
+func TestCollectorOrdering(t *testing.T) {
+	c, ctx := errgroup.NewCollector[int](context.Background())
+	const n = 8
+	for i := 0; i < n; i++ {
+		i := i
+		c.Go(func(ctx context.Context) (int, error) {
+			// Reverse completion order relative to submission order.
+			time.Sleep(time.Duration(n-i) * time.Millisecond)
+			return i, nil
+		})
+	}
+	got, err := c.Wait()
+	if err != nil {
+		t.Fatalf("c.Wait() = _, %v; want nil", err)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Errorf("got[%d] = %d; want %d", i, v, i)
+		}
+	}
+	if ctx.Err() == nil {
+		t.Errorf("ctx.Err() = nil; want non-nil after Wait")
+	}
+}
+func TestCollectorResultsStreaming(t *testing.T) {
+	c, _ := errgroup.NewCollector[int](context.Background())
+	const n = 5
+	for i := 0; i < n; i++ {
+		i := i
+		c.Go(func(ctx context.Context) (int, error) {
+			return i, nil
+		})
+	}
+	// Wait is called first; ranging over Results afterwards delivers every
+	// queued value and then ends, since Wait is what closes it.
+	got, err := c.Wait()
+	if err != nil {
+		t.Fatalf("c.Wait() = _, %v; want nil", err)
+	}
+	if len(got) != n {
+		t.Errorf("len(Wait() results) = %d; want %d", len(got), n)
+	}
+	seen := make(map[int]bool)
+	for v := range c.Results() {
+		seen[v] = true
+	}
+	if len(seen) != n {
+		t.Errorf("Results() delivered %d distinct values; want %d", len(seen), n)
+	}
+}
+func TestCollectorBackpressure(t *testing.T) {
+	c, _ := errgroup.NewCollector[int](context.Background())
+	const n = 5
+	for i := 0; i < n; i++ {
+		i := i
+		c.Go(func(ctx context.Context) (int, error) {
+			return i, nil
+		})
+	}
+	if _, err := c.Wait(); err != nil {
+		t.Fatalf("c.Wait() = _, %v; want nil", err)
+	}
+	results := c.Results()
+	seen := make(map[int]bool)
+	for v := range results {
+		// A slow consumer must not cause values to be dropped or
+		// delivered more than once.
+		time.Sleep(time.Millisecond)
+		if seen[v] {
+			t.Errorf("value %d delivered more than once", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != n {
+		t.Errorf("Results() delivered %d distinct values; want %d", len(seen), n)
+	}
+}
+func TestCollectorCancellation(t *testing.T) {
+	c, ctx := errgroup.NewCollector[int](context.Background())
+	errBoom := errors.New("errgroup_test: boom")
+	c.Go(func(ctx context.Context) (int, error) {
+		return 0, errBoom
+	})
+	c.Go(func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if _, err := c.Wait(); err != errBoom {
+		t.Errorf("c.Wait() = _, %v; want %v", err, errBoom)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("ctx.Err() = nil; want non-nil after an error cancels the Collector")
+	}
+}
+func TestCollectorFinalizerCleanup(t *testing.T) {
+	blockers := make(chan struct{})
+	func() {
+		c, _ := errgroup.NewCollector[int](context.Background())
+		c.Go(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(blockers)
+			return 0, ctx.Err()
+		})
+	}()
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		select {
+		case <-blockers:
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	t.Error("goroutine blocked on ctx.Done() was not released after the Collector became unreachable")
+}
+func TestCollectorResultsWithoutWaitReleasesForwarder(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	func() {
+		c, _ := errgroup.NewCollector[int](context.Background())
+		c.Go(func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		// Start the forward goroutine, but drop c without ever calling
+		// Wait, which is what would otherwise close and drain it.
+		_ = c.Results()
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("runtime.NumGoroutine() = %d after GC; want <= baseline %d", runtime.NumGoroutine(), baseline)
+}
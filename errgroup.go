@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
 // A Group is a collection of goroutines working on subtasks that are part of
@@ -27,6 +28,25 @@ type Group struct {
 	terminateOnce sync.Once
 	panic         interface{}
 	goexit        bool
+	sem           chan token
+	active        int32
+
+	// PanicAsError selects how Wait reports a panic recovered from a
+	// goroutine started via Go. When false (the default), Wait re-panics
+	// with the recovered value, matching this package's historical
+	// behavior. When true, Wait instead returns the panic as an error,
+	// built by OnPanic if set, or as a *PanicError otherwise.
+	//
+	// PanicAsError has no effect on runtime.Goexit, which Wait always
+	// propagates by invoking runtime.Goexit itself.
+	PanicAsError bool
+
+	// OnPanic, if non-nil, is called with the value recovered from a
+	// goroutine's panic and the stack trace captured at the panic site
+	// (nil if no trace was captured) to produce the error Wait returns
+	// when PanicAsError is true. If OnPanic is nil, or returns a nil
+	// error, Wait falls back to returning a *PanicError.
+	OnPanic func(recovered interface{}, stack []byte) error
 }
 
 // WithContext returns a new Group and an associated Context derived from ctx.
@@ -36,10 +56,15 @@ type Group struct {
 // calls Stop on the returned Group, or the first time Wait returns — whichever
 // occurs first.
 //
+// As with New, a finalizer registered on the returned Group cancels its
+// Context if the Group is never otherwise cleaned up; see Detach to opt out.
+//
 // Deprecated: use New instead, and defer a call to Stop to clean up.
 func WithContext(ctx context.Context) (*Group, context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
-	return &Group{cancelCtx: cancel, cancelOnWait: true}, ctx
+	g := &Group{cancelCtx: cancel, cancelOnWait: true}
+	runtime.SetFinalizer(g, finalizeGroup)
+	return g, ctx
 }
 
 // New returns a new Group and an associated Context derived from ctx.
@@ -50,9 +75,32 @@ func WithContext(ctx context.Context) (*Group, context.Context) {
 //
 // Stopping the Group releases resources associated with its Context, so code
 // should call Stop as soon as the Group is no longer needed.
+//
+// New also registers a finalizer on the returned Group that cancels its
+// Context once the Group becomes unreachable, as a backstop for callers that
+// forget to call Stop. Call Detach on the Group to opt out of this behavior.
 func New(ctx context.Context) (*Group, context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
-	return &Group{cancelCtx: cancel}, ctx
+	g := &Group{cancelCtx: cancel}
+	runtime.SetFinalizer(g, finalizeGroup)
+	return g, ctx
+}
+
+// finalizeGroup is registered by New and WithContext as g's finalizer, so
+// that a Group dropped without a call to Stop still releases its Context
+// and lets goroutines observing ctx.Done() exit, instead of leaking them
+// forever.
+func finalizeGroup(g *Group) {
+	g.cancel()
+}
+
+// Detach removes the finalizer registered by New or WithContext, opting g
+// out of finalizer-driven cancellation. Call Detach when g itself may become
+// unreachable while its Context is still in use (for example, when only the
+// Context is handed across an API boundary), so the finalizer doesn't
+// cancel that Context out from under code that still relies on it.
+func (g *Group) Detach() {
+	runtime.SetFinalizer(g, nil)
 }
 
 // Wait blocks until all goroutines in the group have exited.
@@ -68,6 +116,9 @@ func (g *Group) Wait() error {
 		g.cancel()
 	}
 	if g.panic != nil {
+		if g.PanicAsError {
+			return g.panicAsError()
+		}
 		panic(g.panic)
 	}
 	if g.goexit {
@@ -76,6 +127,18 @@ func (g *Group) Wait() error {
 	return g.err
 }
 
+// panicAsError converts a panic recovered from a goroutine into an error,
+// per PanicAsError, instead of re-panicking.
+func (g *Group) panicAsError() error {
+	value, stack := panicValueAndStack(g.panic)
+	if g.OnPanic != nil {
+		if err := g.OnPanic(value, stack); err != nil {
+			return err
+		}
+	}
+	return &PanicError{Value: value, Stack: stack}
+}
+
 // Stop cancels the Context associated with g, if any, then waits for all
 // goroutines started by the Go method to exit.
 func (g *Group) Stop() {
@@ -90,13 +153,62 @@ func (g *Group) cancel() {
 	})
 }
 
+// A token occupies a slot in Group's concurrency-limiting semaphore.
+type token struct{}
+
+// SetLimit limits the number of active goroutines in this group to at most
+// n. A limit of zero or less removes any existing limit.
+//
+// Any subsequent call to Go will block until the number of active goroutines
+// is below the new limit; TryGo will report false instead of blocking.
+//
+// SetLimit panics if any goroutine started by Go or TryGo is still active.
+func (g *Group) SetLimit(n int) {
+	if atomic.LoadInt32(&g.active) != 0 {
+		panic(fmt.Errorf("errgroup: SetLimit called while goroutines are still active"))
+	}
+	if n <= 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan token, n)
+}
+
 // Go calls the given function in a new goroutine,
 // adding that goroutine to the group.
 //
 // The first goroutine in the group that returns a non-nil error, panics, or
 // invokes runtime.Goexit will cancel the group.
+//
+// If the group's limit, set via SetLimit, has been reached, Go blocks until
+// a slot is free.
 func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- token{}
+	}
+	g.launch(f)
+}
+
+// TryGo calls the given function in a new goroutine, adding that goroutine
+// to the group, only if the group's limit, set via SetLimit, has not been
+// reached. It reports whether the goroutine was started.
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- token{}:
+		default:
+			return false
+		}
+	}
+	g.launch(f)
+	return true
+}
+
+// launch starts f in a new goroutine belonging to the group. The caller
+// must have already acquired a semaphore token, if any, for this goroutine.
+func (g *Group) launch(f func() error) {
 	g.wg.Add(1)
+	atomic.AddInt32(&g.active, 1)
 	go func() {
 		var goexiting bool
 		// ⚠ Even though we recover (and save) any panic from f, runtime.Goexit
@@ -109,6 +221,10 @@ func (g *Group) Go(f func() error) {
 					g.cancel()
 				})
 			}
+			if g.sem != nil {
+				<-g.sem
+			}
+			atomic.AddInt32(&g.active, -1)
 			g.wg.Done()
 		}()
 		panicValue, err := doubleDeferSandwich(&goexiting, f)
@@ -160,6 +276,36 @@ func doubleDeferSandwich(goexiting *bool, f func() error) (panicValue interface{
 	return panicValue, err
 }
 
+// PanicError is the error returned from Wait when a goroutine started via Go
+// panicked and the Group's PanicAsError field is set, selecting the
+// return-as-error behavior instead of the default re-panic. If OnPanic is set
+// and returns a non-nil error, Wait returns that error instead.
+type PanicError struct {
+	// Value is the value recovered from the panic.
+	Value interface{}
+	// Stack is the stack trace captured at the point of the panic, or nil
+	// if Value already implemented error and so no trace was captured
+	// (see errorOrStack).
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	if len(p.Stack) == 0 {
+		return fmt.Sprintf("errgroup: panic: %v", p.Value)
+	}
+	return fmt.Sprintf("errgroup: panic: %v\n\n%s", p.Value, p.Stack)
+}
+
+// panicValueAndStack extracts the recovered panic value and, if captured,
+// its stack trace from a value stored by doubleDeferSandwich (either an
+// error or a panicStack; see errorOrStack).
+func panicValueAndStack(p interface{}) (value interface{}, stack []byte) {
+	if ps, ok := p.(panicStack); ok {
+		return ps.value, ps.stack
+	}
+	return p, nil
+}
+
 // A panicStack is an arbitrary value recovered from a panic
 // augmented with the stack trace at which the panic occurred.
 type panicStack struct {
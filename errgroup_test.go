@@ -10,8 +10,11 @@ import (
 	"github.com/DmitriyMV/errgroup"
 	"net/http"
 	"os"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var (
@@ -241,6 +244,134 @@ func TestPanic(t *testing.T) {
 		}
 	})
 }
+
+// waitWithPanicAsError is terminateInGroup's counterpart for the
+// PanicAsError path: it builds a Group with PanicAsError set, runs terminate
+// alongside a goroutine that waits on ctx.Done(), and returns Wait's result
+// directly instead of recovering a re-panic.
+func waitWithPanicAsError(t *testing.T, setup func(g *errgroup.Group), terminate func() error) error {
+	t.Helper()
+	g, ctx := errgroup.New(context.Background())
+	defer g.Stop()
+	g.PanicAsError = true
+	if setup != nil {
+		setup(g)
+	}
+	var waited = false
+	g.Go(func() error {
+		<-ctx.Done()
+		waited = true
+		return ctx.Err()
+	})
+	defer func() {
+		if !waited {
+			t.Errorf("did not wait for other goroutines to exit")
+		}
+	}()
+	g.Go(terminate)
+	return g.Wait()
+}
+func TestPanicAsError(t *testing.T) {
+	t.Run("<nil>", func(t *testing.T) {
+		// Since Go 1.21, panic(nil) is promoted to a non-nil
+		// *runtime.PanicNilError by default; opt back into the legacy
+		// untyped-nil behavior this subtest exercises.
+		t.Setenv("GODEBUG", "panicnil=1")
+		err := waitWithPanicAsError(t, nil, func() error {
+			panic(nil)
+		})
+		var panicErr *errgroup.PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("Wait() = %v; want *errgroup.PanicError", err)
+		}
+		if !strings.HasPrefix(panicErr.Error(), "errgroup: panic: <nil>") {
+			t.Errorf("panicErr.Error() = %q; want prefix %q", panicErr.Error(), "errgroup: panic: <nil>")
+		}
+	})
+	t.Run("non-error", func(t *testing.T) {
+		const s = "some string"
+		err := waitWithPanicAsError(t, nil, func() error {
+			panic(s)
+		})
+		var panicErr *errgroup.PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("Wait() = %v; want *errgroup.PanicError", err)
+		}
+		if panicErr.Value != s {
+			t.Errorf("panicErr.Value = %v; want %q", panicErr.Value, s)
+		}
+		if len(panicErr.Stack) == 0 {
+			t.Errorf("panicErr.Stack is empty; want a captured stack trace")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		errPanic := errors.New("errPanic")
+		err := waitWithPanicAsError(t, nil, func() error {
+			panic(errPanic)
+		})
+		var panicErr *errgroup.PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("Wait() = %v; want *errgroup.PanicError", err)
+		}
+		if panicErr.Value != error(errPanic) {
+			t.Errorf("panicErr.Value = %v; want %v", panicErr.Value, errPanic)
+		}
+	})
+}
+func TestPanicAsErrorGoexit(t *testing.T) {
+	// As in TestGoexit, runtime.Goexit must be exercised from a separate
+	// goroutine, so the result is handed back over a channel.
+	c := make(chan error, 1)
+	t.Run("goexit via Skip", func(inner *testing.T) {
+		defer close(c)
+		c <- waitWithPanicAsError(t, nil, func() error {
+			inner.Skip("goexit!")
+			return nil
+		})
+	})
+	if got := <-c; got != nil {
+		t.Errorf("Wait() returned %v; want runtime.Goexit (unaffected by PanicAsError)", got)
+	}
+}
+func TestOnPanicHook(t *testing.T) {
+	sentinel := errors.New("errgroup_test: handled panic")
+	var gotValue interface{}
+	var gotStack []byte
+	err := waitWithPanicAsError(t, func(g *errgroup.Group) {
+		g.OnPanic = func(recovered interface{}, stack []byte) error {
+			gotValue = recovered
+			gotStack = stack
+			return sentinel
+		}
+	}, func() error {
+		panic("boom")
+	})
+	if err != sentinel {
+		t.Errorf("Wait() = %v; want %v", err, sentinel)
+	}
+	if gotValue != "boom" {
+		t.Errorf("OnPanic recovered = %v; want %q", gotValue, "boom")
+	}
+	if len(gotStack) == 0 {
+		t.Errorf("OnPanic stack is empty; want a captured stack trace")
+	}
+}
+func TestOnPanicHookFallsBackOnNilError(t *testing.T) {
+	err := waitWithPanicAsError(t, func(g *errgroup.Group) {
+		g.OnPanic = func(recovered interface{}, stack []byte) error {
+			return nil
+		}
+	}, func() error {
+		panic("boom")
+	})
+	var panicErr *errgroup.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Wait() = %v; want *errgroup.PanicError", err)
+	}
+	if panicErr.Value != "boom" {
+		t.Errorf("panicErr.Value = %v; want %q", panicErr.Value, "boom")
+	}
+}
 func TestGoexit(t *testing.T) {
 	// We have to test runtime.Goexit from a separate goroutine: testing.T itself
 	// uses runtime.Goexit for SkipNow and FailNow, so it gets cranky if a test
@@ -258,3 +389,195 @@ func TestGoexit(t *testing.T) {
 		t.Errorf("panicked with %v; want runtime.Goexit()", got)
 	}
 }
+func TestSetLimit(t *testing.T) {
+	var g errgroup.Group
+	g.SetLimit(1)
+
+	const n = 10
+	var active int32
+	var maxActive int32
+	for i := 0; i < n; i++ {
+		g.Go(func() error {
+			cur := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				max := atomic.LoadInt32(&maxActive)
+				if cur <= max || atomic.CompareAndSwapInt32(&maxActive, max, cur) {
+					break
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("g.Wait() = %v; want nil", err)
+	}
+	if maxActive != 1 {
+		t.Errorf("observed %d goroutines active at once; want at most 1", maxActive)
+	}
+}
+func TestSetLimitPanicsWhileActive(t *testing.T) {
+	var g errgroup.Group
+	started := make(chan struct{})
+	release := make(chan struct{})
+	g.Go(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetLimit did not panic with a goroutine active")
+		}
+	}()
+	g.SetLimit(1)
+}
+func TestTryGo(t *testing.T) {
+	var g errgroup.Group
+	g.SetLimit(1)
+
+	block := make(chan struct{})
+	if !g.TryGo(func() error {
+		<-block
+		return nil
+	}) {
+		t.Fatalf("TryGo() = false; want true for the first goroutine under the limit")
+	}
+	if g.TryGo(func() error { return nil }) {
+		t.Errorf("TryGo() = true; want false once the limit is saturated")
+	}
+	close(block)
+	if err := g.Wait(); err != nil {
+		t.Errorf("g.Wait() = %v; want nil", err)
+	}
+	if !g.TryGo(func() error { return nil }) {
+		t.Errorf("TryGo() = false; want true once the slot was released")
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("g.Wait() = %v; want nil", err)
+	}
+}
+func TestLimitTokenReleasedOnPanic(t *testing.T) {
+	freshGroup := func() *errgroup.Group {
+		g := &errgroup.Group{PanicAsError: true}
+		g.SetLimit(1)
+		return g
+	}
+	// A fresh, limit-1 Group is used per iteration: once a Group records a
+	// panic it keeps reporting it, so this alone can't show whether the
+	// semaphore token itself was released; TryGo below does.
+	for i := 0; i < 5; i++ {
+		g := freshGroup()
+		g.Go(func() error {
+			panic("boom")
+		})
+		if err := g.Wait(); err == nil {
+			t.Fatalf("iteration %d: g.Wait() = nil; want a *errgroup.PanicError", i)
+		}
+	}
+	g := freshGroup()
+	g.Go(func() error {
+		panic("boom")
+	})
+	g.Wait()
+	if !g.TryGo(func() error { return nil }) {
+		t.Errorf("TryGo() = false after the panicking goroutine exited; the token was never released")
+	}
+	g.Wait()
+}
+func TestLimitTokenReleasedOnGoexit(t *testing.T) {
+	var g errgroup.Group
+	g.SetLimit(1)
+	c := make(chan interface{}, 1)
+	t.Run("goexit via Skip", func(inner *testing.T) {
+		defer close(c)
+		defer func() {
+			c <- recover()
+		}()
+		g.Go(func() error {
+			inner.Skip("goexit!")
+			return nil
+		})
+		g.Wait()
+	})
+	if got := <-c; got != nil {
+		t.Errorf("panicked with %v; want runtime.Goexit()", got)
+	}
+	// g's goexit bit is now set, so any further call to g.Wait from this
+	// (non-subtest) goroutine would invoke runtime.Goexit here too, which
+	// testing treats as a fatal test failure. Check the token was released
+	// with TryGo alone, synchronizing on a channel instead of Wait.
+	done := make(chan struct{})
+	if !g.TryGo(func() error {
+		close(done)
+		return nil
+	}) {
+		t.Errorf("TryGo() = false after a goroutine invoked runtime.Goexit; the token was never released")
+	}
+	<-done
+}
+func waitForCondition(t *testing.T, tries int, delay time.Duration, done <-chan struct{}) bool {
+	t.Helper()
+	for i := 0; i < tries; i++ {
+		runtime.GC()
+		select {
+		case <-done:
+			return true
+		case <-time.After(delay):
+		}
+	}
+	return false
+}
+func TestGroupFinalizerCancelsContext(t *testing.T) {
+	done := make(chan struct{})
+	var ctx context.Context
+	func() {
+		var g *errgroup.Group
+		g, ctx = errgroup.New(context.Background())
+		go func() {
+			<-ctx.Done()
+			close(done)
+		}()
+		_ = g // g is intentionally dropped without calling Stop.
+	}()
+	if !waitForCondition(t, 50, 20*time.Millisecond, done) {
+		t.Error("Group's Context was not canceled after the Group became unreachable")
+	}
+}
+func TestGroupFinalizerReleasesGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	func() {
+		_, ctx := errgroup.New(context.Background())
+		go func() {
+			<-ctx.Done()
+		}()
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("runtime.NumGoroutine() = %d after GC; want <= baseline %d", runtime.NumGoroutine(), baseline)
+}
+func TestGroupDetachOptsOut(t *testing.T) {
+	done := make(chan struct{})
+	var ctx context.Context
+	func() {
+		var g *errgroup.Group
+		g, ctx = errgroup.New(context.Background())
+		g.Detach()
+		go func() {
+			<-ctx.Done()
+			close(done)
+		}()
+		_ = g
+	}()
+	if waitForCondition(t, 10, 20*time.Millisecond, done) {
+		t.Error("Context was canceled even though Detach was called")
+	}
+}